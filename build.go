@@ -1,10 +1,16 @@
 package dotnetpublish
 
 import (
+	// Aliased because the packit.BuildFunc parameter below is itself named
+	// `context`, shadowing the stdlib package of the same name.
+	gocontext "context"
 	"fmt"
 	"io/ioutil"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"syscall"
+	"time"
 
 	"github.com/Masterminds/semver"
 	"github.com/paketo-buildpacks/packit"
@@ -20,7 +26,7 @@ type SourceRemover interface {
 
 //go:generate faux --interface PublishProcess --output fakes/publish_process.go
 type PublishProcess interface {
-	Execute(workingDir, rootDir, projectPath, outputPath string, flags []string) error
+	Execute(ctx gocontext.Context, workingDir, rootDir, projectPath, outputPath string, flags []string) error
 }
 
 //go:generate faux --interface CommandConfigParser --output fakes/command_config_parser.go
@@ -28,11 +34,20 @@ type CommandConfigParser interface {
 	ParseFlagsFromEnvVar(envVar string) ([]string, error)
 }
 
+//go:generate faux --interface CacheKeyer --output fakes/cache_keyer.go
+type CacheKeyer interface {
+	Key(workingDir, nugetConfigPath string) (string, error)
+}
+
 func Build(
 	sourceRemover SourceRemover,
 	publishProcess PublishProcess,
 	buildpackYMLParser BuildpackYMLParser,
 	configParser CommandConfigParser,
+	nugetConfigMerger NuGetConfigMerger,
+	cacheKeyer CacheKeyer,
+	releaseArchiver ReleaseArchiver,
+	projectParser ProjectParser,
 	clock chronos.Clock,
 	logger scribe.Logger,
 ) packit.BuildFunc {
@@ -65,16 +80,22 @@ func Build(
 			return packit.BuildResult{}, err
 		}
 
-		// An optional binding that allows users to provide their own NuGet.Config file
-		// via a service binding. Since a private registry can be used, it's possible
-		// the NuGet.Config contains credentials. Relevent Microsoft docs:
+		// An optional binding that allows users to provide their own NuGet.Config file(s)
+		// via one or more service bindings, e.g. a corporate feed alongside GitHub
+		// Packages. Since a private registry can be used, it's possible the
+		// NuGet.Config contains credentials. Relevent Microsoft docs:
 		// https://docs.microsoft.com/en-us/nuget/consume-packages/consuming-packages-authenticated-feeds
 		// https://docs.microsoft.com/en-us/nuget/consume-packages/configuring-nuget-behavior#how-settings-are-applied
+		var nugetConfigPath string
 		serviceBindingResolver := servicebindings.NewResolver()
-		nugetConfig, err := serviceBindingResolver.ResolveOne("nuget", "", context.Platform.Path)
-		if err == nil {
+		nugetBindings, err := serviceBindingResolver.Resolve("nuget", "", context.Platform.Path)
+		if err != nil {
+			return packit.BuildResult{}, err
+		}
+
+		if len(nugetBindings) > 0 {
 			logger.Process("Using NuGet.Config binding")
-			nugetConfigPath, err := setupNuGetConfig(nugetConfig, context.WorkingDir)
+			nugetConfigPath, err = setupNuGetConfig(nugetConfigMerger, nugetBindings, context.WorkingDir)
 			if err != nil {
 				return packit.BuildResult{}, err
 			}
@@ -82,12 +103,133 @@ func Build(
 			defer os.Remove(nugetConfigPath)
 		}
 
+		// Restore the ~/.nuget/packages cache from a previous build, keyed by the
+		// contents of the project files, lockfile, and NuGet.Config. When the key
+		// matches, `dotnet publish` is told to skip restore entirely.
+		nugetCacheLayer, err := context.Layers.Get("nuget-cache")
+		if err != nil {
+			return packit.BuildResult{}, err
+		}
+
+		disableRestoreCache := os.Getenv("BP_DOTNET_DISABLE_RESTORE_CACHE") == "true"
+
+		var cacheKey string
+		if !disableRestoreCache {
+			cacheKey, err = cacheKeyer.Key(context.WorkingDir, nugetConfigPath)
+			if err != nil {
+				return packit.BuildResult{}, err
+			}
+
+			if nugetCacheLayer.Metadata == nil {
+				nugetCacheLayer.Metadata = map[string]interface{}{}
+			}
+
+			if cachedKey, ok := nugetCacheLayer.Metadata["cache_sha"].(string); ok && cachedKey == cacheKey {
+				logger.Subprocess("Reusing cached NuGet packages")
+				flags = append(flags, "--no-restore")
+			}
+
+			nugetCacheLayer.Cache = true
+
+			err = os.Setenv("NUGET_PACKAGES", nugetCacheLayer.Path)
+			if err != nil {
+				return packit.BuildResult{}, err
+			}
+			defer os.Unsetenv("NUGET_PACKAGES")
+		}
+
+		// Bound the publish process so that a CI timeout or a SIGTERM from the
+		// buildpack lifecycle cancels `dotnet publish` cleanly instead of
+		// orphaning it.
+		ctx := gocontext.Background()
+		if rawTimeout, ok := os.LookupEnv("BP_DOTNET_PUBLISH_TIMEOUT"); ok {
+			timeout, err := time.ParseDuration(rawTimeout)
+			if err != nil {
+				return packit.BuildResult{}, fmt.Errorf("failed to parse $BP_DOTNET_PUBLISH_TIMEOUT: %w", err)
+			}
+
+			var cancel gocontext.CancelFunc
+			ctx, cancel = gocontext.WithTimeout(ctx, timeout)
+			defer cancel()
+		}
+
+		ctx, stop := signal.NotifyContext(ctx, syscall.SIGTERM)
+		defer stop()
+
+		// When the project multi-targets, auto-detect every declared target
+		// framework and runtime identifier and publish each one into its own
+		// subdirectory, rather than requiring the user to run the buildpack
+		// once per target. With a single detected target, behavior is
+		// unchanged: one publish straight into tempDir.
+		var publishTargets []publishTarget
+		if os.Getenv("BP_DOTNET_PUBLISH_ALL_TARGETS") == "true" {
+			metadata, err := projectParser.Parse(filepath.Join(context.WorkingDir, projectPath))
+			if err != nil {
+				return packit.BuildResult{}, err
+			}
+			publishTargets = fanOutPublishTargets(metadata)
+		}
+
 		logger.Process("Executing build process")
-		err = publishProcess.Execute(context.WorkingDir, os.Getenv("DOTNET_ROOT"), projectPath, tempDir, flags)
+		if len(publishTargets) > 1 {
+			for _, target := range publishTargets {
+				targetFlags := append([]string{}, flags...)
+				if target.TargetFramework != "" {
+					targetFlags = append(targetFlags, "--framework", target.TargetFramework)
+				}
+				if target.RuntimeIdentifier != "" {
+					targetFlags = append(targetFlags, "--runtime", target.RuntimeIdentifier)
+				}
+
+				logger.Subprocess("Publishing %s", target.outputDirName())
+				err = publishProcess.Execute(ctx, context.WorkingDir, os.Getenv("DOTNET_ROOT"), projectPath, filepath.Join(tempDir, target.outputDirName()), targetFlags)
+				if err != nil {
+					return packit.BuildResult{}, err
+				}
+			}
+		} else {
+			err = publishProcess.Execute(ctx, context.WorkingDir, os.Getenv("DOTNET_ROOT"), projectPath, tempDir, flags)
+			if err != nil {
+				return packit.BuildResult{}, err
+			}
+		}
+
+		var layers []packit.Layer
+		if !disableRestoreCache {
+			nugetCacheLayer.Metadata["cache_sha"] = cacheKey
+			layers = append(layers, nugetCacheLayer)
+		}
+
+		// Optionally package the publish output into a reproducible release
+		// archive before the source code (and the output directory) is
+		// removed. Especially useful for self-contained single-file
+		// publishes, where the archive contents are exactly what gets shipped.
+		archiveFormats, err := parseArchiveFormats(os.Getenv("BP_DOTNET_EMIT_ARCHIVE"))
 		if err != nil {
 			return packit.BuildResult{}, err
 		}
 
+		if len(archiveFormats) > 0 {
+			releaseLayer, err := context.Layers.Get("release")
+			if err != nil {
+				return packit.BuildResult{}, err
+			}
+			releaseLayer.Launch = true
+
+			logger.Process("Packaging release archive")
+			for _, format := range archiveFormats {
+				destPath := filepath.Join(releaseLayer.Path, fmt.Sprintf("app.%s", format))
+				err = releaseArchiver.Archive(tempDir, destPath, format)
+				if err != nil {
+					return packit.BuildResult{}, err
+				}
+				logger.Subprocess("Wrote %s", destPath)
+			}
+			logger.Break()
+
+			layers = append(layers, releaseLayer)
+		}
+
 		logger.Process("Removing source code")
 		logger.Break()
 		err = sourceRemover.Remove(context.WorkingDir, tempDir, ".dotnet_root")
@@ -100,28 +242,26 @@ func Build(
 			return packit.BuildResult{}, fmt.Errorf("could not remove temp directory: %w", err)
 		}
 
-		return packit.BuildResult{}, nil
+		return packit.BuildResult{Layers: layers}, nil
 	}
 }
 
-func setupNuGetConfig(nugetConfig servicebindings.Binding, workingDir string) (string, error) {
+func setupNuGetConfig(merger NuGetConfigMerger, bindings []servicebindings.Binding, workingDir string) (string, error) {
 	// NOTE: NuGet.Config filename is case-sensitive
 	// https://github.com/NuGet/Home/issues/1427
-	nugetConfigPath := filepath.Join(nugetConfig.Path, "NuGet.Config")
-	// Move the NuGet.Config to the workspace folder
+	// Write the merged NuGet.Config to the workspace folder.
 	// Until the dotnet publish and restore are separated,
 	// The NuGet.Config MUST exist in the project directory (or above)
 	// Once restore is implemented, use -configFile flag
 	// see RFC 0003-publish-build-process-config.md
-	nugetConfigData, err := ioutil.ReadFile(nugetConfigPath)
-
+	mergedConfig, err := merger.Merge(bindings)
 	if err != nil {
 		return "", err
 	}
 
 	workDirNugetConfig := filepath.Join(workingDir, "NuGet.Config")
 
-	err = ioutil.WriteFile(workDirNugetConfig, nugetConfigData, 0644)
+	err = ioutil.WriteFile(workDirNugetConfig, mergedConfig, 0644)
 	if err != nil {
 		return "", err
 	}
@@ -2,6 +2,7 @@ package dotnetpublish_test
 
 import (
 	"bytes"
+	gocontext "context"
 	"errors"
 	"io/ioutil"
 	"os"
@@ -19,17 +20,47 @@ import (
 	. "github.com/onsi/gomega"
 )
 
+// recordingSourceRemover reproduces the real SourceRemover's behavior (glob
+// every top-level entry of publishOutputDir and move it into workingDir), so
+// that the multi-target fan-out test below can assert on the resulting
+// on-disk layout instead of just on what Build passes to a fake.
+type recordingSourceRemover struct{}
+
+func (recordingSourceRemover) Remove(workingDir, publishOutputDir string, excludedFiles ...string) error {
+	matches, err := filepath.Glob(filepath.Join(publishOutputDir, "*"))
+	if err != nil {
+		return err
+	}
+
+	for _, match := range matches {
+		err = os.Rename(match, filepath.Join(workingDir, filepath.Base(match)))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 func testBuild(t *testing.T, context spec.G, it spec.S) {
 	var (
 		Expect = NewWithT(t).Expect
 
-		workingDir         string
-		rootManager        *fakes.RootManager
+		workingDir  string
+		layersDir   string
+		platformDir string
+
 		sourceRemover      *fakes.SourceRemover
 		publishProcess     *fakes.PublishProcess
 		buildpackYMLParser *fakes.BuildpackYMLParser
-		build              packit.BuildFunc
-		timestamp          time.Time
+		configParser       *fakes.CommandConfigParser
+		nugetConfigMerger  *fakes.NuGetConfigMerger
+		cacheKeyer         *fakes.CacheKeyer
+		releaseArchiver    *fakes.ReleaseArchiver
+		projectParser      *fakes.ProjectParser
+
+		build     packit.BuildFunc
+		timestamp time.Time
 
 		buffer *bytes.Buffer
 	)
@@ -39,17 +70,33 @@ func testBuild(t *testing.T, context spec.G, it spec.S) {
 		workingDir, err = ioutil.TempDir("", "working-dir")
 		Expect(err).NotTo(HaveOccurred())
 
+		layersDir, err = ioutil.TempDir("", "layers-dir")
+		Expect(err).NotTo(HaveOccurred())
+
+		platformDir, err = ioutil.TempDir("", "platform-dir")
+		Expect(err).NotTo(HaveOccurred())
+
 		Expect(ioutil.WriteFile(filepath.Join(workingDir, "buildpack.yml"), nil, 0600)).To(Succeed())
 
-		rootManager = &fakes.RootManager{}
+		// Setting the project path directly bypasses the buildpack.yml
+		// fallback (and its accompanying deprecation warning, which requires
+		// a real semantic buildpack version) for the tests that don't care
+		// about that code path specifically.
+		os.Setenv("BP_DOTNET_PROJECT_PATH", "some/project/path")
+
 		sourceRemover = &fakes.SourceRemover{}
 		publishProcess = &fakes.PublishProcess{}
 
 		buildpackYMLParser = &fakes.BuildpackYMLParser{}
 		buildpackYMLParser.ParseProjectPathCall.Returns.ProjectFilePath = "some/project/path"
 
+		configParser = &fakes.CommandConfigParser{}
+		nugetConfigMerger = &fakes.NuGetConfigMerger{}
+		cacheKeyer = &fakes.CacheKeyer{}
+		releaseArchiver = &fakes.ReleaseArchiver{}
+		projectParser = &fakes.ProjectParser{}
+
 		os.Setenv("DOTNET_ROOT", "some-existing-root-dir")
-		os.Setenv("SDK_LOCATION", "some-sdk-location")
 
 		buffer = bytes.NewBuffer(nil)
 		logger := scribe.NewLogger(buffer)
@@ -59,94 +106,323 @@ func testBuild(t *testing.T, context spec.G, it spec.S) {
 			return timestamp
 		})
 
-		build = dotnetpublish.Build(rootManager, sourceRemover, publishProcess, buildpackYMLParser, clock, logger)
+		build = dotnetpublish.Build(sourceRemover, publishProcess, buildpackYMLParser, configParser, nugetConfigMerger, cacheKeyer, releaseArchiver, projectParser, clock, logger)
 	})
 
 	it.After(func() {
 		os.Unsetenv("DOTNET_ROOT")
-		os.Unsetenv("SDK_LOCATION")
+		os.Unsetenv("BP_DOTNET_PROJECT_PATH")
+		os.Unsetenv("BP_DOTNET_PUBLISH_ALL_TARGETS")
+		os.Unsetenv("BP_DOTNET_DISABLE_RESTORE_CACHE")
+		os.Unsetenv("BP_DOTNET_EMIT_ARCHIVE")
 
 		Expect(os.RemoveAll(workingDir)).To(Succeed())
+		Expect(os.RemoveAll(layersDir)).To(Succeed())
+		Expect(os.RemoveAll(platformDir)).To(Succeed())
 	})
 
-	it("returns a build result", func() {
-		result, err := build(packit.BuildContext{
-			WorkingDir: workingDir,
-			BuildpackInfo: packit.BuildpackInfo{
-				Name:    "Some Buildpack",
-				Version: "some-version",
-			},
+	context("single target", func() {
+		it("returns a build result", func() {
+			result, err := build(packit.BuildContext{
+				WorkingDir: workingDir,
+				Layers:     packit.Layers{Path: layersDir},
+				Platform:   packit.Platform{Path: platformDir},
+				BuildpackInfo: packit.BuildpackInfo{
+					Name:    "Some Buildpack",
+					Version: "some-version",
+				},
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.Layers).To(HaveLen(1))
+			Expect(result.Layers[0].Name).To(Equal("nuget-cache"))
+
+			Expect(sourceRemover.RemoveCall.Receives.WorkingDir).To(Equal(workingDir))
+			Expect(sourceRemover.RemoveCall.Receives.PublishOutputDir).To(MatchRegexp(`dotnet-publish-output\d+$`))
+			Expect(sourceRemover.RemoveCall.Receives.ExcludedFiles).To(ConsistOf(".dotnet_root"))
+
+			Expect(publishProcess.ExecuteCall.CallCount).To(Equal(1))
+			Expect(publishProcess.ExecuteCall.Receives.WorkingDir).To(Equal(workingDir))
+			Expect(publishProcess.ExecuteCall.Receives.ProjectPath).To(Equal("some/project/path"))
+			Expect(publishProcess.ExecuteCall.Receives.OutputPath).To(MatchRegexp(`dotnet-publish-output\d+$`))
+
+			Expect(projectParser.ParseCall.CallCount).To(Equal(0), "the project is only parsed when multi-target publishing is requested")
+
+			Expect(buffer.String()).To(ContainSubstring("Some Buildpack some-version"))
+			Expect(buffer.String()).To(ContainSubstring("Executing build process"))
 		})
-		Expect(err).NotTo(HaveOccurred())
-		Expect(result).To(Equal(packit.BuildResult{}))
 
-		Expect(rootManager.SetupCall.Receives.Root).To(Equal(filepath.Join(workingDir, ".dotnet-root")))
-		Expect(rootManager.SetupCall.Receives.ExistingRoot).To(Equal("some-existing-root-dir"))
-		Expect(rootManager.SetupCall.Receives.SdkLocation).To(Equal("some-sdk-location"))
+		context("failure cases", func() {
+			context("when the project path cannot be parsed from buildpack.yml", func() {
+				it.Before(func() {
+					os.Unsetenv("BP_DOTNET_PROJECT_PATH")
+					buildpackYMLParser.ParseProjectPathCall.Returns.Err = errors.New("some-error")
+				})
 
-		Expect(sourceRemover.RemoveCall.Receives.WorkingDir).To(Equal(workingDir))
-		Expect(sourceRemover.RemoveCall.Receives.PublishOutputDir).To(MatchRegexp(`dotnet-publish-output\d+`))
-		Expect(sourceRemover.RemoveCall.Receives.ExcludedFiles).To(ConsistOf([]string{".dotnet-root", ".dotnet_root"}))
+				it("returns an error", func() {
+					_, err := build(packit.BuildContext{WorkingDir: workingDir, Platform: packit.Platform{Path: platformDir}})
+					Expect(err).To(MatchError("some-error"))
+				})
+			})
 
-		Expect(publishProcess.ExecuteCall.Receives.WorkingDir).To(Equal(workingDir))
-		Expect(publishProcess.ExecuteCall.Receives.RootDir).To(Equal(filepath.Join(workingDir, ".dotnet-root")))
-		Expect(publishProcess.ExecuteCall.Receives.ProjectPath).To(Equal("some/project/path"))
-		Expect(publishProcess.ExecuteCall.Receives.OutputPath).To(MatchRegexp(`dotnet-publish-output\d+`))
+			context("when the publish process fails", func() {
+				it.Before(func() {
+					publishProcess.ExecuteCall.Returns.Error = errors.New("some-error")
+				})
 
-		Expect(buffer.String()).To(ContainSubstring("Some Buildpack some-version"))
-		Expect(buffer.String()).To(ContainSubstring("Executing build process"))
+				it("returns an error", func() {
+					_, err := build(packit.BuildContext{
+						WorkingDir: workingDir,
+						Layers:     packit.Layers{Path: layersDir},
+						Platform:   packit.Platform{Path: platformDir},
+					})
+					Expect(err).To(MatchError("some-error"))
+				})
+			})
+
+			context("when the source code cannot be removed", func() {
+				it.Before(func() {
+					sourceRemover.RemoveCall.Returns.Error = errors.New("some-error")
+				})
+
+				it("returns an error", func() {
+					_, err := build(packit.BuildContext{
+						WorkingDir: workingDir,
+						Layers:     packit.Layers{Path: layersDir},
+						Platform:   packit.Platform{Path: platformDir},
+					})
+					Expect(err).To(MatchError("some-error"))
+				})
+			})
+		})
 	})
 
-	context("failure cases", func() {
-		context("when the DOTNET_ROOT can not be found", func() {
+	context("publish timeout", func() {
+		context("when BP_DOTNET_PUBLISH_TIMEOUT is set to a valid duration", func() {
 			it.Before(func() {
-				rootManager.SetupCall.Returns.Error = errors.New("some-error")
+				os.Setenv("BP_DOTNET_PUBLISH_TIMEOUT", "5m")
 			})
 
-			it("returns an error", func() {
+			it.After(func() {
+				os.Unsetenv("BP_DOTNET_PUBLISH_TIMEOUT")
+			})
+
+			it("bounds the context passed to the publish process with a deadline", func() {
 				_, err := build(packit.BuildContext{
 					WorkingDir: workingDir,
+					Layers:     packit.Layers{Path: layersDir},
+					Platform:   packit.Platform{Path: platformDir},
 				})
-				Expect(err).To(MatchError("some-error"))
+				Expect(err).NotTo(HaveOccurred())
+
+				_, hasDeadline := publishProcess.ExecuteCall.Receives.Ctx.Deadline()
+				Expect(hasDeadline).To(BeTrue())
 			})
 		})
 
-		context("when the source code cannot be removed", func() {
+		context("when BP_DOTNET_PUBLISH_TIMEOUT is not a valid duration", func() {
 			it.Before(func() {
-				sourceRemover.RemoveCall.Returns.Error = errors.New("some-error")
+				os.Setenv("BP_DOTNET_PUBLISH_TIMEOUT", "not-a-duration")
+			})
+
+			it.After(func() {
+				os.Unsetenv("BP_DOTNET_PUBLISH_TIMEOUT")
 			})
 
 			it("returns an error", func() {
 				_, err := build(packit.BuildContext{
 					WorkingDir: workingDir,
+					Layers:     packit.Layers{Path: layersDir},
+					Platform:   packit.Platform{Path: platformDir},
 				})
-				Expect(err).To(MatchError("some-error"))
+				Expect(err).To(MatchError(ContainSubstring("failed to parse $BP_DOTNET_PUBLISH_TIMEOUT")))
+			})
+		})
+	})
+
+	context("service binding resolution", func() {
+		context("when there is no bindings directory", func() {
+			it("does not attempt to merge a NuGet.Config", func() {
+				_, err := build(packit.BuildContext{
+					WorkingDir: workingDir,
+					Layers:     packit.Layers{Path: layersDir},
+					Platform:   packit.Platform{Path: platformDir},
+				})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(nugetConfigMerger.MergeCall.CallCount).To(Equal(0))
 			})
 		})
 
-		context("when the buildpack.yml can not be pased", func() {
+		context("when a binding fails to load", func() {
 			it.Before(func() {
-				buildpackYMLParser.ParseProjectPathCall.Returns.Err = errors.New("some-error")
+				// A binding directory with no "type" entry and no legacy
+				// "metadata" directory is invalid per the bindings spec, and
+				// is used here to prove that resolving bindings with
+				// Resolve (rather than the previous ResolveOne, which
+				// silently skipped the binding on any error) now fails the
+				// build instead of swallowing the error.
+				Expect(os.MkdirAll(filepath.Join(platformDir, "bindings", "bad-binding"), os.ModePerm)).To(Succeed())
 			})
-			it("returns an error", func() {
+
+			it("fails the build", func() {
 				_, err := build(packit.BuildContext{
 					WorkingDir: workingDir,
+					Layers:     packit.Layers{Path: layersDir},
+					Platform:   packit.Platform{Path: platformDir},
 				})
-				Expect(err).To(MatchError("some-error"))
+				Expect(err).To(MatchError(ContainSubstring("bad-binding")))
+			})
+		})
+	})
+
+	context("restore cache", func() {
+		it("reuses the cached packages when the cache key matches", func() {
+			cacheKeyer.KeyCall.Returns.String = "some-cache-key"
+
+			Expect(os.MkdirAll(layersDir, os.ModePerm)).To(Succeed())
+			Expect(ioutil.WriteFile(filepath.Join(layersDir, "nuget-cache.toml"), []byte("cache = true\n\n[metadata]\n  cache_sha = \"some-cache-key\"\n"), 0600)).To(Succeed())
+
+			_, err := build(packit.BuildContext{
+				WorkingDir: workingDir,
+				Layers:     packit.Layers{Path: layersDir},
+				Platform:   packit.Platform{Path: platformDir},
 			})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(publishProcess.ExecuteCall.Receives.Flags).To(ContainElement("--no-restore"))
 		})
 
-		context("when the publish process fails", func() {
+		it("skips the cache entirely when BP_DOTNET_DISABLE_RESTORE_CACHE is set", func() {
+			os.Setenv("BP_DOTNET_DISABLE_RESTORE_CACHE", "true")
+
+			result, err := build(packit.BuildContext{
+				WorkingDir: workingDir,
+				Layers:     packit.Layers{Path: layersDir},
+				Platform:   packit.Platform{Path: platformDir},
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.Layers).To(BeEmpty())
+			Expect(cacheKeyer.KeyCall.CallCount).To(Equal(0))
+		})
+	})
+
+	context("multi-target publishing", func() {
+		it.Before(func() {
+			os.Setenv("BP_DOTNET_PUBLISH_ALL_TARGETS", "true")
+
+			projectParser.ParseCall.Returns.ProjectMetadata = dotnetpublish.ProjectMetadata{
+				TargetFrameworks:   []string{"net6.0", "net7.0"},
+				RuntimeIdentifiers: []string{"linux-x64", "linux-arm64"},
+			}
+		})
+
+		it("publishes once per target framework/runtime identifier tuple, writing each into its own subdirectory", func() {
+			type executeArgs struct {
+				OutputPath string
+				Flags      []string
+			}
+			var calls []executeArgs
+
+			publishProcess.ExecuteCall.Stub = func(ctx gocontext.Context, workingDir, rootDir, projectPath, outputPath string, flags []string) error {
+				calls = append(calls, executeArgs{OutputPath: outputPath, Flags: flags})
+				// Simulate dotnet publish actually writing an artifact so the
+				// source-removal assertion below has something real to move.
+				Expect(os.MkdirAll(outputPath, os.ModePerm)).To(Succeed())
+				return ioutil.WriteFile(filepath.Join(outputPath, "app.dll"), []byte(filepath.Base(outputPath)), 0600)
+			}
+
+			build = dotnetpublish.Build(recordingSourceRemover{}, publishProcess, buildpackYMLParser, configParser, nugetConfigMerger, cacheKeyer, releaseArchiver, projectParser, chronos.NewClock(func() time.Time { return timestamp }), scribe.NewLogger(buffer))
+
+			_, err := build(packit.BuildContext{
+				WorkingDir: workingDir,
+				Layers:     packit.Layers{Path: layersDir},
+				Platform:   packit.Platform{Path: platformDir},
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(projectParser.ParseCall.Receives.ProjectFile).To(Equal(filepath.Join(workingDir, "some/project/path")))
+			Expect(calls).To(HaveLen(4))
+
+			var outputPaths []string
+			for _, call := range calls {
+				outputPaths = append(outputPaths, filepath.Base(call.OutputPath))
+				Expect(call.Flags).To(Or(
+					ContainElements("--framework", "net6.0", "--runtime", "linux-x64"),
+					ContainElements("--framework", "net6.0", "--runtime", "linux-arm64"),
+					ContainElements("--framework", "net7.0", "--runtime", "linux-x64"),
+					ContainElements("--framework", "net7.0", "--runtime", "linux-arm64"),
+				))
+			}
+			Expect(outputPaths).To(ConsistOf("net6.0-linux-x64", "net6.0-linux-arm64", "net7.0-linux-x64", "net7.0-linux-arm64"))
+
+			// The real SourceRemover call is unchanged from the single-target
+			// case: it still globs the top-level entries of tempDir and moves
+			// each one (here, each per-target subdirectory as a whole) into
+			// workingDir, so every target's output survives the source-removal
+			// step without SourceRemover needing to know about fan-out at all.
+			for _, dir := range outputPaths {
+				Expect(filepath.Join(workingDir, dir, "app.dll")).To(BeARegularFile())
+			}
+		})
+
+		context("when only a single target is detected", func() {
+			it.Before(func() {
+				projectParser.ParseCall.Returns.ProjectMetadata = dotnetpublish.ProjectMetadata{
+					TargetFrameworks: []string{"net6.0"},
+				}
+			})
+
+			it("publishes once directly into tempDir, matching single-target behavior", func() {
+				_, err := build(packit.BuildContext{
+					WorkingDir: workingDir,
+					Layers:     packit.Layers{Path: layersDir},
+					Platform:   packit.Platform{Path: platformDir},
+				})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(publishProcess.ExecuteCall.CallCount).To(Equal(1))
+				Expect(publishProcess.ExecuteCall.Receives.OutputPath).To(MatchRegexp(`dotnet-publish-output\d+$`))
+				Expect(publishProcess.ExecuteCall.Receives.Flags).NotTo(ContainElement("--framework"))
+			})
+		})
+
+		context("when the project file cannot be parsed", func() {
 			it.Before(func() {
-				publishProcess.ExecuteCall.Returns.Error = errors.New("some-error")
+				projectParser.ParseCall.Returns.Error = errors.New("some-error")
 			})
 
 			it("returns an error", func() {
 				_, err := build(packit.BuildContext{
 					WorkingDir: workingDir,
+					Layers:     packit.Layers{Path: layersDir},
+					Platform:   packit.Platform{Path: platformDir},
 				})
 				Expect(err).To(MatchError("some-error"))
 			})
 		})
 	})
+
+	context("release archive", func() {
+		it("packages the publish output before removing source code", func() {
+			os.Setenv("BP_DOTNET_EMIT_ARCHIVE", "tar.gz")
+
+			result, err := build(packit.BuildContext{
+				WorkingDir: workingDir,
+				Layers:     packit.Layers{Path: layersDir},
+				Platform:   packit.Platform{Path: platformDir},
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(releaseArchiver.ArchiveCall.Receives.Format).To(Equal(dotnetpublish.TarGzArchiveFormat))
+			Expect(releaseArchiver.ArchiveCall.Receives.DestPath).To(HaveSuffix("app.tar.gz"))
+
+			var sawReleaseLayer bool
+			for _, layer := range result.Layers {
+				if layer.Name == "release" {
+					sawReleaseLayer = true
+					Expect(layer.Launch).To(BeTrue())
+				}
+			}
+			Expect(sawReleaseLayer).To(BeTrue())
+		})
+	})
 }
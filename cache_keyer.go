@@ -0,0 +1,71 @@
+package dotnetpublish
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// NuGetCacheKeyer computes a content-addressable key for the NuGet restore
+// cache. Build uses the key to decide whether a previous build's
+// ~/.nuget/packages layer can be reused, avoiding a redundant `dotnet
+// restore` on unchanged dependencies.
+type NuGetCacheKeyer struct{}
+
+func NewNuGetCacheKeyer() NuGetCacheKeyer {
+	return NuGetCacheKeyer{}
+}
+
+// Key hashes the contents of every *.csproj, *.fsproj, and
+// packages.lock.json file found under workingDir, along with the resolved
+// NuGet.Config (if nugetConfigPath is non-empty), so that any change to
+// project references, package versions, or feed configuration produces a
+// new key.
+func (k NuGetCacheKeyer) Key(workingDir, nugetConfigPath string) (string, error) {
+	var paths []string
+
+	err := filepath.Walk(workingDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if info.IsDir() {
+			return nil
+		}
+
+		name := info.Name()
+		if name == "packages.lock.json" || strings.HasSuffix(name, ".csproj") || strings.HasSuffix(name, ".fsproj") {
+			paths = append(paths, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to discover NuGet cache key inputs: %w", err)
+	}
+
+	sort.Strings(paths)
+
+	if nugetConfigPath != "" {
+		paths = append(paths, nugetConfigPath)
+	}
+
+	hash := sha256.New()
+	for _, path := range paths {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return "", fmt.Errorf("failed to read %s while computing NuGet cache key: %w", path, err)
+		}
+
+		_, err = hash.Write(data)
+		if err != nil {
+			return "", fmt.Errorf("failed to hash %s while computing NuGet cache key: %w", path, err)
+		}
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
@@ -0,0 +1,86 @@
+package dotnetpublish_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	dotnetpublish "github.com/paketo-buildpacks/dotnet-publish"
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+)
+
+func testNuGetCacheKeyer(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		workingDir string
+		keyer      dotnetpublish.NuGetCacheKeyer
+	)
+
+	it.Before(func() {
+		var err error
+		workingDir, err = ioutil.TempDir("", "working-dir")
+		Expect(err).NotTo(HaveOccurred())
+
+		keyer = dotnetpublish.NewNuGetCacheKeyer()
+	})
+
+	it.After(func() {
+		Expect(os.RemoveAll(workingDir)).To(Succeed())
+	})
+
+	context("Key", func() {
+		it("is stable for unchanged project files", func() {
+			Expect(ioutil.WriteFile(filepath.Join(workingDir, "some-app.csproj"), []byte("some-content"), 0600)).To(Succeed())
+
+			first, err := keyer.Key(workingDir, "")
+			Expect(err).NotTo(HaveOccurred())
+
+			second, err := keyer.Key(workingDir, "")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(first).To(Equal(second))
+		})
+
+		it("changes when a csproj file changes", func() {
+			csprojPath := filepath.Join(workingDir, "some-app.csproj")
+			Expect(ioutil.WriteFile(csprojPath, []byte("some-content"), 0600)).To(Succeed())
+
+			before, err := keyer.Key(workingDir, "")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(ioutil.WriteFile(csprojPath, []byte("some-other-content"), 0600)).To(Succeed())
+
+			after, err := keyer.Key(workingDir, "")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(before).NotTo(Equal(after))
+		})
+
+		it("incorporates packages.lock.json and the resolved NuGet.Config", func() {
+			Expect(ioutil.WriteFile(filepath.Join(workingDir, "some-app.fsproj"), []byte("some-content"), 0600)).To(Succeed())
+			Expect(ioutil.WriteFile(filepath.Join(workingDir, "packages.lock.json"), []byte("some-lock-content"), 0600)).To(Succeed())
+
+			nugetConfigPath := filepath.Join(workingDir, "NuGet.Config")
+			Expect(ioutil.WriteFile(nugetConfigPath, []byte("some-config-content"), 0600)).To(Succeed())
+
+			withConfig, err := keyer.Key(workingDir, nugetConfigPath)
+			Expect(err).NotTo(HaveOccurred())
+
+			withoutConfig, err := keyer.Key(workingDir, "")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(withConfig).NotTo(Equal(withoutConfig))
+
+			Expect(ioutil.WriteFile(filepath.Join(workingDir, "packages.lock.json"), []byte("some-other-lock-content"), 0600)).To(Succeed())
+
+			withChangedLock, err := keyer.Key(workingDir, nugetConfigPath)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(withChangedLock).NotTo(Equal(withConfig))
+		})
+	})
+}
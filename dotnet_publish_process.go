@@ -0,0 +1,122 @@
+package dotnetpublish
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/paketo-buildpacks/packit/chronos"
+	"github.com/paketo-buildpacks/packit/scribe"
+)
+
+// DotnetPublishProcess invokes `dotnet publish` as a child process placed in
+// its own process group. This allows the context passed to Execute (bounded
+// by BP_DOTNET_PUBLISH_TIMEOUT in Build, or canceled on SIGTERM from the
+// platform) to terminate the whole msbuild process tree on timeout instead
+// of leaving orphaned child processes behind.
+type DotnetPublishProcess struct {
+	logger scribe.Logger
+	clock  chronos.Clock
+}
+
+func NewDotnetPublishProcess(logger scribe.Logger, clock chronos.Clock) DotnetPublishProcess {
+	return DotnetPublishProcess{
+		logger: logger,
+		clock:  clock,
+	}
+}
+
+func (p DotnetPublishProcess) Execute(ctx context.Context, workingDir, rootDir, projectPath, outputPath string, flags []string) error {
+	args := append([]string{"publish", filepath.Join(workingDir, projectPath), "--output", outputPath}, flags...)
+
+	cmd := exec.CommandContext(ctx, filepath.Join(rootDir, "dotnet"), args...)
+	cmd.Dir = workingDir
+	cmd.Env = os.Environ()
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return fmt.Errorf("failed to create stdout pipe for 'dotnet publish': %w", err)
+	}
+
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return fmt.Errorf("failed to create stderr pipe for 'dotnet publish': %w", err)
+	}
+
+	p.logger.Subprocess("Running 'dotnet %s'", strings.Join(args, " "))
+
+	var duration time.Duration
+	duration, err = p.clock.Measure(func() error {
+		if startErr := cmd.Start(); startErr != nil {
+			return fmt.Errorf("failed to start 'dotnet publish': %w", startErr)
+		}
+
+		stopWatchingForCancel := make(chan struct{})
+		defer close(stopWatchingForCancel)
+		go func() {
+			select {
+			case <-ctx.Done():
+				// Kill the whole process group so that msbuild/vbcscompiler
+				// children spawned by `dotnet publish` do not leak.
+				_ = syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+			case <-stopWatchingForCancel:
+			}
+		}()
+
+		// stdout and stderr are scanned concurrently, but both would
+		// otherwise call logger.Action from separate goroutines and race on
+		// the logger's underlying writer. Funnel both into a single channel
+		// so one goroutine does all the logging.
+		lines := make(chan string)
+		var wg sync.WaitGroup
+		wg.Add(2)
+		go p.streamLines(stdout, lines, &wg)
+		go p.streamLines(stderr, lines, &wg)
+
+		logDone := make(chan struct{})
+		go func() {
+			defer close(logDone)
+			for line := range lines {
+				p.logger.Action(line)
+			}
+		}()
+
+		wg.Wait()
+		close(lines)
+		<-logDone
+
+		waitErr := cmd.Wait()
+		if ctx.Err() != nil {
+			return fmt.Errorf("dotnet publish: %w", ctx.Err())
+		}
+		return waitErr
+	})
+
+	if err != nil {
+		p.logger.Action("Failed after %s", duration)
+		return fmt.Errorf("failed to execute 'dotnet publish': %w", err)
+	}
+
+	p.logger.Action("Completed in %s", duration)
+	p.logger.Break()
+
+	return nil
+}
+
+func (p DotnetPublishProcess) streamLines(r io.Reader, lines chan<- string, wg *sync.WaitGroup) {
+	defer wg.Done()
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		lines <- scanner.Text()
+	}
+}
@@ -0,0 +1,94 @@
+package dotnetpublish_test
+
+import (
+	"bytes"
+	// Aliased because spec test functions conventionally name their
+	// spec.G parameter `context`, shadowing the stdlib package.
+	gocontext "context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	dotnetpublish "github.com/paketo-buildpacks/dotnet-publish"
+	"github.com/paketo-buildpacks/packit/chronos"
+	"github.com/paketo-buildpacks/packit/scribe"
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+)
+
+func testDotnetPublishProcess(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		workingDir string
+		rootDir    string
+		process    dotnetpublish.DotnetPublishProcess
+		buffer     *bytes.Buffer
+	)
+
+	it.Before(func() {
+		var err error
+		workingDir, err = ioutil.TempDir("", "working-dir")
+		Expect(err).NotTo(HaveOccurred())
+
+		rootDir, err = ioutil.TempDir("", "dotnet-root")
+		Expect(err).NotTo(HaveOccurred())
+
+		buffer = bytes.NewBuffer(nil)
+		logger := scribe.NewLogger(buffer)
+
+		timestamp := time.Now()
+		clock := chronos.NewClock(func() time.Time {
+			return timestamp
+		})
+
+		process = dotnetpublish.NewDotnetPublishProcess(logger, clock)
+	})
+
+	it.After(func() {
+		Expect(os.RemoveAll(workingDir)).To(Succeed())
+		Expect(os.RemoveAll(rootDir)).To(Succeed())
+	})
+
+	writeFakeDotnet := func(script string) {
+		Expect(ioutil.WriteFile(filepath.Join(rootDir, "dotnet"), []byte(script), 0755)).To(Succeed())
+	}
+
+	it("executes 'dotnet publish' and streams stdout/stderr through the logger", func() {
+		writeFakeDotnet("#!/bin/sh\necho stdout-output\necho stderr-output 1>&2\n")
+
+		err := process.Execute(gocontext.Background(), workingDir, rootDir, "some/project/path", "some-output-dir", []string{"--flag", "value"})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(buffer.String()).To(ContainSubstring("Running 'dotnet publish"))
+		Expect(buffer.String()).To(ContainSubstring("stdout-output"))
+		Expect(buffer.String()).To(ContainSubstring("stderr-output"))
+		Expect(buffer.String()).To(ContainSubstring("Completed in"))
+	})
+
+	context("failure cases", func() {
+		it("returns an error when the process exits non-zero", func() {
+			writeFakeDotnet("#!/bin/sh\nexit 1\n")
+
+			err := process.Execute(gocontext.Background(), workingDir, rootDir, "", "some-output-dir", nil)
+			Expect(err).To(MatchError(ContainSubstring("failed to execute 'dotnet publish'")))
+		})
+
+		context("when the context is canceled before the process finishes", func() {
+			it("terminates the process instead of waiting for it to finish", func() {
+				writeFakeDotnet("#!/bin/sh\nsleep 30\n")
+
+				ctx, cancel := gocontext.WithTimeout(gocontext.Background(), 200*time.Millisecond)
+				defer cancel()
+
+				started := time.Now()
+				err := process.Execute(ctx, workingDir, rootDir, "", "some-output-dir", nil)
+				Expect(err).To(HaveOccurred())
+				Expect(time.Since(started)).To(BeNumerically("<", 10*time.Second))
+			})
+		})
+	})
+}
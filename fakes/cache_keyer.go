@@ -0,0 +1,31 @@
+package fakes
+
+import "sync"
+
+type CacheKeyer struct {
+	KeyCall struct {
+		mutex     sync.Mutex
+		CallCount int
+		Receives  struct {
+			WorkingDir      string
+			NugetConfigPath string
+		}
+		Returns struct {
+			String string
+			Error  error
+		}
+		Stub func(string, string) (string, error)
+	}
+}
+
+func (f *CacheKeyer) Key(param1 string, param2 string) (string, error) {
+	f.KeyCall.mutex.Lock()
+	defer f.KeyCall.mutex.Unlock()
+	f.KeyCall.CallCount++
+	f.KeyCall.Receives.WorkingDir = param1
+	f.KeyCall.Receives.NugetConfigPath = param2
+	if f.KeyCall.Stub != nil {
+		return f.KeyCall.Stub(param1, param2)
+	}
+	return f.KeyCall.Returns.String, f.KeyCall.Returns.Error
+}
@@ -0,0 +1,33 @@
+package fakes
+
+import (
+	"sync"
+
+	"github.com/paketo-buildpacks/packit/servicebindings"
+)
+
+type NuGetConfigMerger struct {
+	MergeCall struct {
+		mutex     sync.Mutex
+		CallCount int
+		Receives  struct {
+			Bindings []servicebindings.Binding
+		}
+		Returns struct {
+			Bytes []byte
+			Error error
+		}
+		Stub func([]servicebindings.Binding) ([]byte, error)
+	}
+}
+
+func (f *NuGetConfigMerger) Merge(param1 []servicebindings.Binding) ([]byte, error) {
+	f.MergeCall.mutex.Lock()
+	defer f.MergeCall.mutex.Unlock()
+	f.MergeCall.CallCount++
+	f.MergeCall.Receives.Bindings = param1
+	if f.MergeCall.Stub != nil {
+		return f.MergeCall.Stub(param1)
+	}
+	return f.MergeCall.Returns.Bytes, f.MergeCall.Returns.Error
+}
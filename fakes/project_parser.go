@@ -0,0 +1,33 @@
+package fakes
+
+import (
+	"sync"
+
+	dotnetpublish "github.com/paketo-buildpacks/dotnet-publish"
+)
+
+type ProjectParser struct {
+	ParseCall struct {
+		mutex     sync.Mutex
+		CallCount int
+		Receives  struct {
+			ProjectFile string
+		}
+		Returns struct {
+			ProjectMetadata dotnetpublish.ProjectMetadata
+			Error           error
+		}
+		Stub func(string) (dotnetpublish.ProjectMetadata, error)
+	}
+}
+
+func (f *ProjectParser) Parse(param1 string) (dotnetpublish.ProjectMetadata, error) {
+	f.ParseCall.mutex.Lock()
+	defer f.ParseCall.mutex.Unlock()
+	f.ParseCall.CallCount++
+	f.ParseCall.Receives.ProjectFile = param1
+	if f.ParseCall.Stub != nil {
+		return f.ParseCall.Stub(param1)
+	}
+	return f.ParseCall.Returns.ProjectMetadata, f.ParseCall.Returns.Error
+}
@@ -0,0 +1,36 @@
+package fakes
+
+import (
+	"sync"
+
+	dotnetpublish "github.com/paketo-buildpacks/dotnet-publish"
+)
+
+type ReleaseArchiver struct {
+	ArchiveCall struct {
+		mutex     sync.Mutex
+		CallCount int
+		Receives  struct {
+			SrcDir   string
+			DestPath string
+			Format   dotnetpublish.ArchiveFormat
+		}
+		Returns struct {
+			Error error
+		}
+		Stub func(string, string, dotnetpublish.ArchiveFormat) error
+	}
+}
+
+func (f *ReleaseArchiver) Archive(param1 string, param2 string, param3 dotnetpublish.ArchiveFormat) error {
+	f.ArchiveCall.mutex.Lock()
+	defer f.ArchiveCall.mutex.Unlock()
+	f.ArchiveCall.CallCount++
+	f.ArchiveCall.Receives.SrcDir = param1
+	f.ArchiveCall.Receives.DestPath = param2
+	f.ArchiveCall.Receives.Format = param3
+	if f.ArchiveCall.Stub != nil {
+		return f.ArchiveCall.Stub(param1, param2, param3)
+	}
+	return f.ArchiveCall.Returns.Error
+}
@@ -0,0 +1,19 @@
+package dotnetpublish_test
+
+import (
+	"testing"
+
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+)
+
+func TestUnitDotnetPublish(t *testing.T) {
+	suite := spec.New("dotnet-publish", spec.Report(report.Terminal{}))
+	suite("Build", testBuild)
+	suite("NuGetConfigMerger", testNuGetConfigMerger)
+	suite("NuGetCacheKeyer", testNuGetCacheKeyer)
+	suite("DotnetPublishProcess", testDotnetPublishProcess)
+	suite("ReleaseArchiver", testReleaseArchiver)
+	suite("ProjectParser", testProjectParser)
+	suite.Run(t)
+}
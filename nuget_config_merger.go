@@ -0,0 +1,166 @@
+package dotnetpublish
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/paketo-buildpacks/packit/servicebindings"
+)
+
+//go:generate faux --interface NuGetConfigMerger --output fakes/nuget_config_merger.go
+type NuGetConfigMerger interface {
+	Merge(bindings []servicebindings.Binding) ([]byte, error)
+}
+
+type nugetConfigXML struct {
+	XMLName        xml.Name `xml:"configuration"`
+	PackageSources struct {
+		Add []nugetConfigAddXML `xml:"add"`
+	} `xml:"packageSources"`
+	PackageSourceCredentials struct {
+		Sources []nugetSourceCredentialXML `xml:",any"`
+	} `xml:"packageSourceCredentials"`
+}
+
+type nugetConfigAddXML struct {
+	Key   string `xml:"key,attr"`
+	Value string `xml:"value,attr"`
+}
+
+type nugetSourceCredentialXML struct {
+	XMLName xml.Name
+	Add     []nugetConfigAddXML `xml:"add"`
+}
+
+// NuGetConfigMerger merges the NuGet.Config files found in multiple
+// `nuget`-typed service bindings into a single NuGet.Config, so that users
+// can wire up more than one private feed (e.g. a corporate feed and GitHub
+// Packages) at the same time.
+type nuGetConfigMerger struct{}
+
+func NewNuGetConfigMerger() nuGetConfigMerger {
+	return nuGetConfigMerger{}
+}
+
+// Merge reads the NuGet.Config carried by each binding and unions their
+// packageSources and packageSourceCredentials entries, deduping by source
+// name. Bindings are applied in order of the BP_DOTNET_NUGET_SOURCE_PRIORITY
+// environment variable (a comma-separated list of binding names), falling
+// back to alphabetical binding name order for any bindings it does not
+// mention. If two bindings declare the same source name with different
+// URLs, Merge fails rather than silently picking one.
+func (m nuGetConfigMerger) Merge(bindings []servicebindings.Binding) ([]byte, error) {
+	ordered := orderBindingsByPriority(bindings, os.Getenv("BP_DOTNET_NUGET_SOURCE_PRIORITY"))
+
+	type source struct {
+		entry nugetConfigAddXML
+		owner string
+	}
+
+	sources := map[string]source{}
+	var sourceOrder []string
+
+	credentials := map[string]nugetSourceCredentialXML{}
+	var credentialOrder []string
+
+	for _, binding := range ordered {
+		configPath := filepath.Join(binding.Path, "NuGet.Config")
+
+		data, err := ioutil.ReadFile(configPath)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, fmt.Errorf("failed to read NuGet.Config for binding %q: %w", binding.Name, err)
+		}
+
+		var parsed nugetConfigXML
+		err = xml.Unmarshal(data, &parsed)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse NuGet.Config for binding %q: %w", binding.Name, err)
+		}
+
+		for _, add := range parsed.PackageSources.Add {
+			if existing, ok := sources[add.Key]; ok {
+				if existing.entry.Value != add.Value {
+					return nil, fmt.Errorf(
+						"conflicting NuGet package source %q: binding %q declares %q but binding %q already declared %q",
+						add.Key, binding.Name, add.Value, existing.owner, existing.entry.Value,
+					)
+				}
+				continue
+			}
+			sources[add.Key] = source{entry: add, owner: binding.Name}
+			sourceOrder = append(sourceOrder, add.Key)
+		}
+
+		for _, credential := range parsed.PackageSourceCredentials.Sources {
+			name := credential.XMLName.Local
+			if _, ok := credentials[name]; ok {
+				continue
+			}
+			credentials[name] = credential
+			credentialOrder = append(credentialOrder, name)
+		}
+	}
+
+	var merged nugetConfigXML
+	for _, key := range sourceOrder {
+		merged.PackageSources.Add = append(merged.PackageSources.Add, sources[key].entry)
+	}
+	for _, name := range credentialOrder {
+		merged.PackageSourceCredentials.Sources = append(merged.PackageSourceCredentials.Sources, credentials[name])
+	}
+
+	out, err := xml.MarshalIndent(merged, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal merged NuGet.Config: %w", err)
+	}
+
+	return append([]byte(xml.Header), out...), nil
+}
+
+// orderBindingsByPriority returns bindings ordered first by any names listed
+// in rawPriority (comma-separated, earlier wins), then alphabetically by
+// binding name for everything else.
+func orderBindingsByPriority(bindings []servicebindings.Binding, rawPriority string) []servicebindings.Binding {
+	byName := map[string]servicebindings.Binding{}
+	names := make([]string, 0, len(bindings))
+	for _, binding := range bindings {
+		byName[binding.Name] = binding
+		names = append(names, binding.Name)
+	}
+	sort.Strings(names)
+
+	seen := map[string]bool{}
+	var order []string
+
+	for _, name := range strings.Split(rawPriority, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" || seen[name] {
+			continue
+		}
+		if _, ok := byName[name]; ok {
+			order = append(order, name)
+			seen[name] = true
+		}
+	}
+
+	for _, name := range names {
+		if !seen[name] {
+			order = append(order, name)
+			seen[name] = true
+		}
+	}
+
+	ordered := make([]servicebindings.Binding, 0, len(order))
+	for _, name := range order {
+		ordered = append(ordered, byName[name])
+	}
+	return ordered
+}
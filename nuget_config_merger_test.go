@@ -0,0 +1,162 @@
+package dotnetpublish_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	dotnetpublish "github.com/paketo-buildpacks/dotnet-publish"
+	"github.com/paketo-buildpacks/packit/servicebindings"
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+)
+
+func testNuGetConfigMerger(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		bindingsDir string
+		merger      dotnetpublish.NuGetConfigMerger
+	)
+
+	it.Before(func() {
+		var err error
+		bindingsDir, err = ioutil.TempDir("", "nuget-bindings")
+		Expect(err).NotTo(HaveOccurred())
+
+		merger = dotnetpublish.NewNuGetConfigMerger()
+	})
+
+	it.After(func() {
+		Expect(os.RemoveAll(bindingsDir)).To(Succeed())
+		Expect(os.Unsetenv("BP_DOTNET_NUGET_SOURCE_PRIORITY")).To(Succeed())
+	})
+
+	writeBinding := func(name, contents string) servicebindings.Binding {
+		path := filepath.Join(bindingsDir, name)
+		Expect(os.MkdirAll(path, os.ModePerm)).To(Succeed())
+		Expect(ioutil.WriteFile(filepath.Join(path, "NuGet.Config"), []byte(contents), 0600)).To(Succeed())
+		return servicebindings.Binding{Name: name, Path: path, Type: "nuget"}
+	}
+
+	context("Merge", func() {
+		it("unions package sources and credentials from multiple bindings", func() {
+			corporate := writeBinding("corporate", `<?xml version="1.0" encoding="utf-8"?>
+<configuration>
+  <packageSources>
+    <add key="corporate-feed" value="https://nuget.corp.example.com/v3/index.json" />
+  </packageSources>
+  <packageSourceCredentials>
+    <corporate-feed>
+      <add key="Username" value="svc-account" />
+      <add key="ClearTextPassword" value="corp-password" />
+    </corporate-feed>
+  </packageSourceCredentials>
+</configuration>`)
+
+			githubPackages := writeBinding("github-packages", `<?xml version="1.0" encoding="utf-8"?>
+<configuration>
+  <packageSources>
+    <add key="github-packages" value="https://nuget.pkg.github.com/some-org/index.json" />
+  </packageSources>
+  <packageSourceCredentials>
+    <github-packages>
+      <add key="Username" value="some-org" />
+      <add key="ClearTextPassword" value="gh-token" />
+    </github-packages>
+  </packageSourceCredentials>
+</configuration>`)
+
+			merged, err := merger.Merge([]servicebindings.Binding{corporate, githubPackages})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(merged)).To(ContainSubstring(`key="corporate-feed" value="https://nuget.corp.example.com/v3/index.json"`))
+			Expect(string(merged)).To(ContainSubstring(`key="github-packages" value="https://nuget.pkg.github.com/some-org/index.json"`))
+			Expect(string(merged)).To(ContainSubstring(`key="ClearTextPassword" value="corp-password"`))
+			Expect(string(merged)).To(ContainSubstring(`key="ClearTextPassword" value="gh-token"`))
+		})
+
+		it("dedupes a source declared identically by more than one binding", func() {
+			first := writeBinding("first", `<configuration>
+  <packageSources>
+    <add key="shared" value="https://nuget.example.com/v3/index.json" />
+  </packageSources>
+</configuration>`)
+			second := writeBinding("second", `<configuration>
+  <packageSources>
+    <add key="shared" value="https://nuget.example.com/v3/index.json" />
+  </packageSources>
+</configuration>`)
+
+			merged, err := merger.Merge([]servicebindings.Binding{first, second})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(merged)).To(ContainSubstring(`key="shared"`))
+			Expect(strings.Count(string(merged), `key="shared"`)).To(Equal(1))
+		})
+
+		context("when BP_DOTNET_NUGET_SOURCE_PRIORITY is set", func() {
+			it("resolves conflicting credentials using the requested binding order", func() {
+				Expect(os.Setenv("BP_DOTNET_NUGET_SOURCE_PRIORITY", "second,first")).To(Succeed())
+
+				first := writeBinding("first", `<configuration>
+  <packageSources>
+    <add key="shared" value="https://nuget.example.com/v3/index.json" />
+  </packageSources>
+  <packageSourceCredentials>
+    <shared>
+      <add key="Username" value="first-user" />
+      <add key="ClearTextPassword" value="first-password" />
+    </shared>
+  </packageSourceCredentials>
+</configuration>`)
+				second := writeBinding("second", `<configuration>
+  <packageSources>
+    <add key="shared" value="https://nuget.example.com/v3/index.json" />
+  </packageSources>
+  <packageSourceCredentials>
+    <shared>
+      <add key="Username" value="second-user" />
+      <add key="ClearTextPassword" value="second-password" />
+    </shared>
+  </packageSourceCredentials>
+</configuration>`)
+
+				merged, err := merger.Merge([]servicebindings.Binding{first, second})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(string(merged)).To(ContainSubstring(`key="ClearTextPassword" value="second-password"`))
+				Expect(string(merged)).NotTo(ContainSubstring("first-password"))
+			})
+		})
+
+		context("failure cases", func() {
+			context("when two bindings declare the same source name with different URLs", func() {
+				it("fails loudly instead of silently picking one", func() {
+					first := writeBinding("first", `<configuration>
+  <packageSources>
+    <add key="shared" value="https://nuget.example.com/v3/index.json" />
+  </packageSources>
+</configuration>`)
+					second := writeBinding("second", `<configuration>
+  <packageSources>
+    <add key="shared" value="https://other.example.com/v3/index.json" />
+  </packageSources>
+</configuration>`)
+
+					_, err := merger.Merge([]servicebindings.Binding{first, second})
+					Expect(err).To(MatchError(ContainSubstring("conflicting NuGet package source \"shared\"")))
+				})
+			})
+
+			context("when a binding's NuGet.Config cannot be parsed", func() {
+				it("returns an error", func() {
+					broken := writeBinding("broken", `not-xml`)
+
+					_, err := merger.Merge([]servicebindings.Binding{broken})
+					Expect(err).To(MatchError(ContainSubstring("failed to parse NuGet.Config")))
+				})
+			})
+		})
+	})
+}
@@ -0,0 +1,163 @@
+package dotnetpublish
+
+import (
+	"encoding/xml"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// ProjectMetadata captures the subset of a .csproj/.fsproj's MSBuild
+// properties that Build needs in order to decide how (and how many times)
+// to invoke `dotnet publish`.
+type ProjectMetadata struct {
+	TargetFrameworks   []string
+	RuntimeIdentifiers []string
+	OutputType         string
+	UseAppHost         bool
+}
+
+//go:generate faux --interface ProjectParser --output fakes/project_parser.go
+type ProjectParser interface {
+	Parse(projectFile string) (ProjectMetadata, error)
+}
+
+type projectFileXML struct {
+	PropertyGroups []struct {
+		Condition          string `xml:"Condition,attr"`
+		TargetFramework    string `xml:"TargetFramework"`
+		TargetFrameworks   string `xml:"TargetFrameworks"`
+		RuntimeIdentifier  string `xml:"RuntimeIdentifier"`
+		RuntimeIdentifiers string `xml:"RuntimeIdentifiers"`
+		OutputType         string `xml:"OutputType"`
+		UseAppHost         string `xml:"UseAppHost"`
+	} `xml:"PropertyGroup"`
+}
+
+// DotnetProjectParser reads the MSBuild properties out of a .csproj or
+// .fsproj file. Both the singular (TargetFramework, RuntimeIdentifier) and
+// plural, semicolon-delimited (TargetFrameworks, RuntimeIdentifiers) forms
+// are supported, since a project may declare either depending on whether it
+// multi-targets.
+//
+// PropertyGroups guarded by an MSBuild Condition (e.g. one RID for Windows,
+// another for Linux) are skipped entirely: this parser does not evaluate
+// MSBuild conditions, and including a conditioned value unconditionally
+// would turn an OS-dependent choice into an extra fan-out target that was
+// never meant to be published on this build image.
+type DotnetProjectParser struct{}
+
+func NewDotnetProjectParser() DotnetProjectParser {
+	return DotnetProjectParser{}
+}
+
+func (p DotnetProjectParser) Parse(projectFile string) (ProjectMetadata, error) {
+	file, err := os.Open(projectFile)
+	if err != nil {
+		return ProjectMetadata{}, fmt.Errorf("failed to open project file: %w", err)
+	}
+	defer file.Close()
+
+	var project projectFileXML
+	err = xml.NewDecoder(file).Decode(&project)
+	if err != nil {
+		return ProjectMetadata{}, fmt.Errorf("failed to parse project file: %w", err)
+	}
+
+	metadata := ProjectMetadata{UseAppHost: true}
+
+	for _, group := range project.PropertyGroups {
+		if group.Condition != "" {
+			continue
+		}
+
+		metadata.TargetFrameworks = append(metadata.TargetFrameworks, splitMSBuildList(group.TargetFrameworks)...)
+		if group.TargetFramework != "" {
+			metadata.TargetFrameworks = append(metadata.TargetFrameworks, group.TargetFramework)
+		}
+
+		metadata.RuntimeIdentifiers = append(metadata.RuntimeIdentifiers, splitMSBuildList(group.RuntimeIdentifiers)...)
+		if group.RuntimeIdentifier != "" {
+			metadata.RuntimeIdentifiers = append(metadata.RuntimeIdentifiers, group.RuntimeIdentifier)
+		}
+
+		if group.OutputType != "" {
+			metadata.OutputType = group.OutputType
+		}
+
+		if group.UseAppHost != "" {
+			metadata.UseAppHost = group.UseAppHost != "false"
+		}
+	}
+
+	metadata.TargetFrameworks = dedupeStrings(metadata.TargetFrameworks)
+	metadata.RuntimeIdentifiers = dedupeStrings(metadata.RuntimeIdentifiers)
+
+	return metadata, nil
+}
+
+// splitMSBuildList splits a semicolon-delimited MSBuild property value
+// (e.g. "net6.0;net7.0") into its individual entries.
+func splitMSBuildList(raw string) []string {
+	var entries []string
+	for _, entry := range strings.Split(raw, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry != "" {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+func dedupeStrings(values []string) []string {
+	seen := map[string]bool{}
+	var deduped []string
+	for _, value := range values {
+		if seen[value] {
+			continue
+		}
+		seen[value] = true
+		deduped = append(deduped, value)
+	}
+	return deduped
+}
+
+// publishTarget is one (target framework, runtime identifier) pair to
+// publish independently when BP_DOTNET_PUBLISH_ALL_TARGETS is set.
+type publishTarget struct {
+	TargetFramework   string
+	RuntimeIdentifier string
+}
+
+// outputDirName is the tempDir subdirectory a target's publish output is
+// written to, e.g. "net6.0-linux-arm64".
+func (t publishTarget) outputDirName() string {
+	if t.RuntimeIdentifier == "" {
+		return t.TargetFramework
+	}
+	return fmt.Sprintf("%s-%s", t.TargetFramework, t.RuntimeIdentifier)
+}
+
+// fanOutPublishTargets expands a project's target frameworks and runtime
+// identifiers into the full set of (tfm, rid) tuples to publish
+// independently, mirroring the way buildpack image descriptors fan out
+// across target os/arch/variant/distribution tuples.
+func fanOutPublishTargets(metadata ProjectMetadata) []publishTarget {
+	frameworks := metadata.TargetFrameworks
+	if len(frameworks) == 0 {
+		frameworks = []string{""}
+	}
+
+	runtimes := metadata.RuntimeIdentifiers
+	if len(runtimes) == 0 {
+		runtimes = []string{""}
+	}
+
+	var targets []publishTarget
+	for _, framework := range frameworks {
+		for _, runtime := range runtimes {
+			targets = append(targets, publishTarget{TargetFramework: framework, RuntimeIdentifier: runtime})
+		}
+	}
+	return targets
+}
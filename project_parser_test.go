@@ -0,0 +1,108 @@
+package dotnetpublish_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	dotnetpublish "github.com/paketo-buildpacks/dotnet-publish"
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+)
+
+func testProjectParser(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		workingDir string
+		parser     dotnetpublish.DotnetProjectParser
+	)
+
+	it.Before(func() {
+		var err error
+		workingDir, err = ioutil.TempDir("", "working-dir")
+		Expect(err).NotTo(HaveOccurred())
+
+		parser = dotnetpublish.NewDotnetProjectParser()
+	})
+
+	it.After(func() {
+		Expect(os.RemoveAll(workingDir)).To(Succeed())
+	})
+
+	context("Parse", func() {
+		it("parses a single target framework", func() {
+			projectPath := filepath.Join(workingDir, "some-app.csproj")
+			Expect(ioutil.WriteFile(projectPath, []byte(`<Project Sdk="Microsoft.NET.Sdk">
+  <PropertyGroup>
+    <TargetFramework>net6.0</TargetFramework>
+    <OutputType>Exe</OutputType>
+  </PropertyGroup>
+</Project>
+`), 0600)).To(Succeed())
+
+			metadata, err := parser.Parse(projectPath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(metadata.TargetFrameworks).To(Equal([]string{"net6.0"}))
+			Expect(metadata.RuntimeIdentifiers).To(BeEmpty())
+			Expect(metadata.OutputType).To(Equal("Exe"))
+			Expect(metadata.UseAppHost).To(BeTrue())
+		})
+
+		it("parses multiple target frameworks and runtime identifiers", func() {
+			projectPath := filepath.Join(workingDir, "some-app.csproj")
+			Expect(ioutil.WriteFile(projectPath, []byte(`<Project Sdk="Microsoft.NET.Sdk">
+  <PropertyGroup>
+    <TargetFrameworks>net6.0;net7.0</TargetFrameworks>
+    <RuntimeIdentifiers>linux-x64;linux-arm64</RuntimeIdentifiers>
+    <UseAppHost>false</UseAppHost>
+  </PropertyGroup>
+</Project>
+`), 0600)).To(Succeed())
+
+			metadata, err := parser.Parse(projectPath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(metadata.TargetFrameworks).To(Equal([]string{"net6.0", "net7.0"}))
+			Expect(metadata.RuntimeIdentifiers).To(Equal([]string{"linux-x64", "linux-arm64"}))
+			Expect(metadata.UseAppHost).To(BeFalse())
+		})
+
+		it("ignores PropertyGroups guarded by an MSBuild Condition", func() {
+			projectPath := filepath.Join(workingDir, "some-app.csproj")
+			Expect(ioutil.WriteFile(projectPath, []byte(`<Project Sdk="Microsoft.NET.Sdk">
+  <PropertyGroup>
+    <TargetFramework>net6.0</TargetFramework>
+  </PropertyGroup>
+  <PropertyGroup Condition="'$(OS)' == 'Windows_NT'">
+    <RuntimeIdentifier>win-x64</RuntimeIdentifier>
+  </PropertyGroup>
+  <PropertyGroup Condition="'$(OS)' != 'Windows_NT'">
+    <RuntimeIdentifier>linux-x64</RuntimeIdentifier>
+  </PropertyGroup>
+</Project>
+`), 0600)).To(Succeed())
+
+			metadata, err := parser.Parse(projectPath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(metadata.TargetFrameworks).To(Equal([]string{"net6.0"}))
+			Expect(metadata.RuntimeIdentifiers).To(BeEmpty())
+		})
+
+		context("failure cases", func() {
+			it("returns an error when the project file does not exist", func() {
+				_, err := parser.Parse(filepath.Join(workingDir, "missing.csproj"))
+				Expect(err).To(MatchError(ContainSubstring("failed to open project file")))
+			})
+
+			it("returns an error when the project file is not valid XML", func() {
+				projectPath := filepath.Join(workingDir, "some-app.csproj")
+				Expect(ioutil.WriteFile(projectPath, []byte("<Project>"), 0600)).To(Succeed())
+
+				_, err := parser.Parse(projectPath)
+				Expect(err).To(MatchError(ContainSubstring("failed to parse project file")))
+			})
+		})
+	})
+}
@@ -0,0 +1,150 @@
+package dotnetpublish_test
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"crypto/sha256"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	dotnetpublish "github.com/paketo-buildpacks/dotnet-publish"
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+)
+
+func testReleaseArchiver(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		srcDir   string
+		destDir  string
+		archiver dotnetpublish.ReleaseArchiver
+	)
+
+	it.Before(func() {
+		var err error
+		srcDir, err = ioutil.TempDir("", "release-src")
+		Expect(err).NotTo(HaveOccurred())
+
+		destDir, err = ioutil.TempDir("", "release-dest")
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(ioutil.WriteFile(filepath.Join(srcDir, "some-app.dll"), []byte("some-contents"), 0644)).To(Succeed())
+		Expect(ioutil.WriteFile(filepath.Join(srcDir, "some-app"), []byte("#!/bin/sh\n"), 0755)).To(Succeed())
+
+		archiver = dotnetpublish.NewReleaseArchiver()
+	})
+
+	it.After(func() {
+		Expect(os.RemoveAll(srcDir)).To(Succeed())
+		Expect(os.RemoveAll(destDir)).To(Succeed())
+	})
+
+	context("Archive", func() {
+		context("tar.gz", func() {
+			it("writes a reproducible, executable-bit-preserving tarball and a checksum sidecar", func() {
+				destPath := filepath.Join(destDir, "app.tar.gz")
+
+				Expect(archiver.Archive(srcDir, destPath, dotnetpublish.TarGzArchiveFormat)).To(Succeed())
+
+				file, err := os.Open(destPath)
+				Expect(err).NotTo(HaveOccurred())
+				defer file.Close()
+
+				gzipReader, err := gzip.NewReader(file)
+				Expect(err).NotTo(HaveOccurred())
+
+				tarReader := tar.NewReader(gzipReader)
+
+				modes := map[string]int64{}
+				for {
+					header, err := tarReader.Next()
+					if err == io.EOF {
+						break
+					}
+					Expect(err).NotTo(HaveOccurred())
+					modes[header.Name] = header.Mode
+					Expect(header.ModTime.Unix()).To(Equal(int64(0)))
+					Expect(header.Uid).To(Equal(0))
+					Expect(header.Gid).To(Equal(0))
+				}
+
+				Expect(modes["some-app"]).To(Equal(int64(0755)))
+				Expect(modes["some-app.dll"]).To(Equal(int64(0644)))
+
+				Expect(filepath.Join(destDir, "SHA256SUMS")).To(BeARegularFile())
+				sums, err := ioutil.ReadFile(filepath.Join(destDir, "SHA256SUMS"))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(string(sums)).To(ContainSubstring("app.tar.gz"))
+			})
+
+			it("produces byte-for-byte identical archives across runs", func() {
+				first := filepath.Join(destDir, "first.tar.gz")
+				second := filepath.Join(destDir, "second.tar.gz")
+
+				Expect(archiver.Archive(srcDir, first, dotnetpublish.TarGzArchiveFormat)).To(Succeed())
+				Expect(archiver.Archive(srcDir, second, dotnetpublish.TarGzArchiveFormat)).To(Succeed())
+
+				firstContents, err := ioutil.ReadFile(first)
+				Expect(err).NotTo(HaveOccurred())
+
+				secondContents, err := ioutil.ReadFile(second)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(sha256.Sum256(firstContents)).To(Equal(sha256.Sum256(secondContents)))
+			})
+		})
+
+		context("zip", func() {
+			it("writes a zip archive preserving executable bits", func() {
+				destPath := filepath.Join(destDir, "app.zip")
+
+				Expect(archiver.Archive(srcDir, destPath, dotnetpublish.ZipArchiveFormat)).To(Succeed())
+
+				reader, err := zip.OpenReader(destPath)
+				Expect(err).NotTo(HaveOccurred())
+				defer reader.Close()
+
+				var sawExecutable bool
+				for _, file := range reader.File {
+					if file.Name == "some-app" {
+						sawExecutable = file.Mode()&0111 != 0
+					}
+				}
+				Expect(sawExecutable).To(BeTrue())
+			})
+
+			it("produces byte-for-byte identical archives across runs regardless of on-disk permission bits", func() {
+				Expect(os.Chmod(filepath.Join(srcDir, "some-app.dll"), 0644)).To(Succeed())
+
+				first := filepath.Join(destDir, "first.zip")
+				Expect(archiver.Archive(srcDir, first, dotnetpublish.ZipArchiveFormat)).To(Succeed())
+
+				Expect(os.Chmod(filepath.Join(srcDir, "some-app.dll"), 0664)).To(Succeed())
+
+				second := filepath.Join(destDir, "second.zip")
+				Expect(archiver.Archive(srcDir, second, dotnetpublish.ZipArchiveFormat)).To(Succeed())
+
+				firstContents, err := ioutil.ReadFile(first)
+				Expect(err).NotTo(HaveOccurred())
+
+				secondContents, err := ioutil.ReadFile(second)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(sha256.Sum256(firstContents)).To(Equal(sha256.Sum256(secondContents)))
+			})
+		})
+
+		context("failure cases", func() {
+			it("returns an error for an unsupported format", func() {
+				err := archiver.Archive(srcDir, filepath.Join(destDir, "app.unknown"), dotnetpublish.ArchiveFormat("unknown"))
+				Expect(err).To(MatchError(ContainSubstring("unsupported release archive format")))
+			})
+		})
+	})
+}